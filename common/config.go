@@ -0,0 +1,63 @@
+package common
+
+// Config defines the structure of the mu.yml config file
+type Config struct {
+	Environments []Environment `yaml:"environments,omitempty"`
+
+	// Parameters holds raw CloudFormation parameter overrides (e.g. from
+	// --param Key=Value on the CLI) that workflows merge into the stack
+	// parameters they would otherwise compute, taking precedence over them.
+	Parameters map[string]string `yaml:"parameters,omitempty"`
+}
+
+// Environment defines a single environment (e.g. dev, staging, prod) that
+// services can be deployed into
+type Environment struct {
+	Name      string    `yaml:"name,omitempty"`
+	Cluster   Cluster   `yaml:"cluster,omitempty"`
+	VpcTarget VpcTarget `yaml:"vpcTarget,omitempty"`
+
+	// Workflows declares named, ordered sequences of steps (e.g. "provision",
+	// "decommission", "rotate") that NewEnvironmentWorkflowRunner can
+	// execute. An environment that doesn't declare a given name falls back
+	// to mu's built-in "provision"/"decommission" workflows.
+	Workflows map[string][]WorkflowStep `yaml:"workflows,omitempty"`
+}
+
+// WorkflowStep names a single step of a named workflow, drawn from the
+// built-in step registry (e.g. "vpc-upsert", "run-shell"). Pre and Post let
+// users inject arbitrary steps - typically run-cfn or run-shell - around a
+// built-in step without forking mu.
+type WorkflowStep struct {
+	Type   string            `yaml:"type"`
+	Params map[string]string `yaml:"params,omitempty"`
+	Pre    []WorkflowStep    `yaml:"pre,omitempty"`
+	Post   []WorkflowStep    `yaml:"post,omitempty"`
+}
+
+// Cluster defines the ECS cluster configuration for an environment
+type Cluster struct {
+	InstanceType string `yaml:"instanceType,omitempty"`
+	DesiredSize  int    `yaml:"desiredSize,omitempty"`
+	MaxSize      int    `yaml:"maxSize,omitempty"`
+	SSHKeyName   string `yaml:"sshKeyName,omitempty"`
+	ImageID      string `yaml:"imageId,omitempty"`
+
+	// InstanceDistribution controls how the ASG spreads instances across
+	// AZs: "spread-az" balances evenly across the environment's own AZs,
+	// "pack" lets the ASG choose freely, and "distribution-group:<env>"
+	// passes <env>'s current cluster instance ids to the cluster stack as
+	// DistributionGroupInstanceIds; the cluster template does not use them
+	// when placing instances.
+	InstanceDistribution string `yaml:"instanceDistribution,omitempty"`
+}
+
+// VpcTarget identifies an existing VPC that mu should deploy into rather than
+// creating a new one. When unset, mu manages the lifecycle of its own VPC.
+type VpcTarget struct {
+	VpcID              string   `yaml:"vpcId,omitempty"`
+	InstanceSubnetType string   `yaml:"instanceSubnetType,omitempty"`
+	PublicSubnetIds    []string `yaml:"publicSubnetIds,omitempty"`
+	PrivateSubnetIds   []string `yaml:"privateSubnetIds,omitempty"`
+	NatGatewayIds      []string `yaml:"natGatewayIds,omitempty"`
+}
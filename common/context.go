@@ -0,0 +1,15 @@
+package common
+
+// Context holds the shared state needed across workflows for a single mu
+// invocation: the resolved config plus clients for talking to AWS.
+type Context struct {
+	Config       Config
+	StackManager StackManager
+}
+
+// NewContext creates an empty Context. Callers are expected to populate
+// Config and StackManager (typically via a config.Loader and the AWS SDK)
+// before handing the context to a workflow.
+func NewContext() *Context {
+	return new(Context)
+}
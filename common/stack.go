@@ -0,0 +1,30 @@
+package common
+
+import "io"
+
+// StackManager is the full set of CloudFormation operations that workflows
+// can perform against a stack.
+type StackManager interface {
+	AwaitFinalStatus(stackName string) string
+	UpsertStack(stackName string, templateBodyReader io.Reader, stackParameters map[string]string, stackTags map[string]string) error
+	DeleteStack(stackName string) error
+	FindLatestImageID(pattern string) (string, error)
+	ListStackInstances(stackName string) ([]string, error)
+	ListRetainedResources(stackName string) ([]string, error)
+	CreateChangeSet(stackName string, templateBodyReader io.Reader, stackParameters map[string]string, stackTags map[string]string) (*ChangeSetSummary, error)
+}
+
+// ChangeSetSummary describes what a step would do without actually doing it,
+// for rendering in dry-run mode: a stack change planned by CreateChangeSet,
+// a stack deletion, or a skipped run-shell command.
+type ChangeSetSummary struct {
+	StackName        string // for "SKIP-SHELL", the skipped command
+	Action           string // "CREATE", "UPDATE", "DELETE", or "SKIP-SHELL"
+	ResourceChanges  []string
+	ParameterChanges []string
+
+	// RetainedResources lists the logical ids of resources with
+	// DeletionPolicy: Retain that a DELETE of StackName would leave behind,
+	// so dry-run output can warn about what survives a terminate step.
+	RetainedResources []string
+}
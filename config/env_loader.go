@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stelligent/mu/common"
+)
+
+// envParamPrefix is the prefix for environment variables that override a raw
+// CloudFormation parameter, e.g. MU_PARAM_DesiredCapacity=5.
+const envParamPrefix = "MU_PARAM_"
+
+// EnvLoader wraps another Loader and layers overrides from MU_* environment
+// variables on top of the config it returns. Struct fields are addressed as
+// MU_ENV_<environment name>_<FIELD>_<NESTED FIELD>..., derived from each
+// field's yaml tag (e.g. MU_ENV_DEV_CLUSTER_INSTANCE_TYPE overrides the
+// instanceType of the "dev" environment's cluster). Raw CloudFormation
+// parameter overrides use MU_PARAM_<Key>.
+type EnvLoader struct {
+	inner Loader
+}
+
+// NewEnvLoader creates an EnvLoader that overrides the config produced by inner.
+func NewEnvLoader(inner Loader) *EnvLoader {
+	return &EnvLoader{inner: inner}
+}
+
+// Paths delegates to the wrapped loader; EnvLoader itself reads no files.
+func (loader *EnvLoader) Paths() []string {
+	return loader.inner.Paths()
+}
+
+// Load resolves the wrapped loader's config, then applies MU_* env var overrides.
+func (loader *EnvLoader) Load(ctx context.Context) (*common.Config, error) {
+	config, err := loader.inner.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range config.Environments {
+		env := &config.Environments[i]
+		prefix := "MU_ENV_" + toEnvSegment(env.Name)
+		applyStructEnvOverrides(reflect.ValueOf(env).Elem(), prefix)
+	}
+
+	applyParamEnvOverrides(config)
+
+	return config, nil
+}
+
+func applyParamEnvOverrides(config *common.Config) {
+	for _, entry := range os.Environ() {
+		if !strings.HasPrefix(entry, envParamPrefix) {
+			continue
+		}
+		parts := strings.SplitN(entry[len(envParamPrefix):], "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		if config.Parameters == nil {
+			config.Parameters = make(map[string]string)
+		}
+		config.Parameters[parts[0]] = parts[1]
+	}
+}
+
+// applyStructEnvOverrides walks a struct's fields, overriding string/int
+// leaves from the environment and recursing into nested structs.
+func applyStructEnvOverrides(v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		envKey := prefix + "_" + toEnvSegment(name)
+		fv := v.Field(i)
+
+		switch fv.Kind() {
+		case reflect.Struct:
+			applyStructEnvOverrides(fv, envKey)
+		case reflect.String:
+			if val, ok := os.LookupEnv(envKey); ok {
+				fv.SetString(val)
+			}
+		case reflect.Int:
+			if val, ok := os.LookupEnv(envKey); ok {
+				if n, err := strconv.Atoi(val); err == nil {
+					fv.SetInt(int64(n))
+				}
+			}
+		}
+	}
+}
+
+// toEnvSegment converts a camelCase yaml field name (e.g. instanceType) into
+// the upper-snake-case segment used in its environment variable name
+// (INSTANCE_TYPE).
+func toEnvSegment(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/stelligent/mu/common"
+	"gopkg.in/yaml.v2"
+)
+
+// FileLoader loads a common.Config by merging a series of YAML files, in
+// order. Files later in the list override fields set by earlier ones (e.g.
+// environment-specific values in mu-dev.yml on top of a base mu.yml).
+// Paths that don't exist are skipped so callers can list optional overrides
+// alongside a required base file.
+type FileLoader struct {
+	paths []string
+}
+
+// NewFileLoader creates a FileLoader that merges the given paths in order.
+func NewFileLoader(paths ...string) *FileLoader {
+	return &FileLoader{paths: paths}
+}
+
+// Paths returns the configured file paths, in merge order.
+func (loader *FileLoader) Paths() []string {
+	return loader.paths
+}
+
+// Load reads and merges every existing path into a single common.Config.
+func (loader *FileLoader) Load(ctx context.Context) (*common.Config, error) {
+	config := new(common.Config)
+	found := 0
+
+	for _, path := range loader.paths {
+		body, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file '%s': %v", path, err)
+		}
+
+		fileConfig := new(common.Config)
+		if err := yaml.Unmarshal(body, fileConfig); err != nil {
+			return nil, fmt.Errorf("unable to parse config file '%s': %v", path, err)
+		}
+
+		config.Environments = mergeEnvironments(config.Environments, fileConfig.Environments)
+		for name, value := range fileConfig.Parameters {
+			if config.Parameters == nil {
+				config.Parameters = make(map[string]string, len(fileConfig.Parameters))
+			}
+			config.Parameters[name] = value
+		}
+		found++
+	}
+
+	if found == 0 {
+		return nil, fmt.Errorf("no config files found among: %v", loader.paths)
+	}
+
+	return config, nil
+}
+
+// mergeEnvironments overlays overrides onto existing, matching entries by
+// Name. An override environment whose name doesn't already appear in
+// existing is appended. Each file is unmarshalled into its own Config, so
+// nothing is merged by yaml.Unmarshal: Load merges Parameters key by key,
+// and environments are merged here.
+func mergeEnvironments(existing []common.Environment, overrides []common.Environment) []common.Environment {
+	merged := make([]common.Environment, len(existing))
+	copy(merged, existing)
+
+	indexByName := make(map[string]int, len(merged))
+	for i, environment := range merged {
+		indexByName[environment.Name] = i
+	}
+
+	for _, override := range overrides {
+		if i, ok := indexByName[override.Name]; ok {
+			merged[i] = mergeEnvironment(merged[i], override)
+		} else {
+			indexByName[override.Name] = len(merged)
+			merged = append(merged, override)
+		}
+	}
+
+	return merged
+}
+
+// mergeEnvironment overlays the non-zero fields of override onto base.
+func mergeEnvironment(base common.Environment, override common.Environment) common.Environment {
+	merged := base
+	merged.Cluster = mergeCluster(base.Cluster, override.Cluster)
+	merged.VpcTarget = mergeVpcTarget(base.VpcTarget, override.VpcTarget)
+
+	for name, steps := range override.Workflows {
+		if merged.Workflows == nil {
+			merged.Workflows = make(map[string][]common.WorkflowStep, len(override.Workflows))
+		}
+		merged.Workflows[name] = steps
+	}
+
+	return merged
+}
+
+// mergeCluster overlays the non-zero fields of override onto base.
+func mergeCluster(base common.Cluster, override common.Cluster) common.Cluster {
+	merged := base
+	if override.InstanceType != "" {
+		merged.InstanceType = override.InstanceType
+	}
+	if override.DesiredSize != 0 {
+		merged.DesiredSize = override.DesiredSize
+	}
+	if override.MaxSize != 0 {
+		merged.MaxSize = override.MaxSize
+	}
+	if override.SSHKeyName != "" {
+		merged.SSHKeyName = override.SSHKeyName
+	}
+	if override.ImageID != "" {
+		merged.ImageID = override.ImageID
+	}
+	if override.InstanceDistribution != "" {
+		merged.InstanceDistribution = override.InstanceDistribution
+	}
+	return merged
+}
+
+// mergeVpcTarget overlays the non-zero fields of override onto base.
+func mergeVpcTarget(base common.VpcTarget, override common.VpcTarget) common.VpcTarget {
+	merged := base
+	if override.VpcID != "" {
+		merged.VpcID = override.VpcID
+	}
+	if override.InstanceSubnetType != "" {
+		merged.InstanceSubnetType = override.InstanceSubnetType
+	}
+	if override.PublicSubnetIds != nil {
+		merged.PublicSubnetIds = override.PublicSubnetIds
+	}
+	if override.PrivateSubnetIds != nil {
+		merged.PrivateSubnetIds = override.PrivateSubnetIds
+	}
+	if override.NatGatewayIds != nil {
+		merged.NatGatewayIds = override.NatGatewayIds
+	}
+	return merged
+}
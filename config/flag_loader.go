@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stelligent/mu/common"
+)
+
+// FlagLoader wraps another Loader and applies CloudFormation parameter
+// overrides collected from repeated `--param Key=Value` CLI flags, the same
+// way ecs-cli maps flags onto CFN parameters. Its overrides take precedence
+// over whatever the wrapped loader resolves, including an EnvLoader's when
+// one is chained inside it.
+type FlagLoader struct {
+	inner  Loader
+	params []string
+}
+
+// NewFlagLoader creates a FlagLoader that applies the given "Key=Value"
+// strings, typically collected from one or more --param flags, on top of
+// whatever inner resolves.
+func NewFlagLoader(inner Loader, params []string) *FlagLoader {
+	return &FlagLoader{inner: inner, params: params}
+}
+
+// Paths delegates to the wrapped loader; FlagLoader itself reads no files.
+func (loader *FlagLoader) Paths() []string {
+	return loader.inner.Paths()
+}
+
+// Load resolves the wrapped loader's config, then applies --param overrides.
+func (loader *FlagLoader) Load(ctx context.Context) (*common.Config, error) {
+	config, err := loader.inner.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, param := range loader.params {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --param '%s', expected Key=Value", param)
+		}
+		if config.Parameters == nil {
+			config.Parameters = make(map[string]string)
+		}
+		config.Parameters[parts[0]] = parts[1]
+	}
+
+	return config, nil
+}
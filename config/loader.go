@@ -0,0 +1,21 @@
+package config
+
+import (
+	"context"
+
+	"github.com/stelligent/mu/common"
+)
+
+// Loader resolves a common.Config from some source - a set of YAML files,
+// environment variables, CLI flags, or a combination of those chained
+// together.
+type Loader interface {
+	// Paths returns the filesystem paths this loader reads from, if any.
+	// Wrapping loaders (EnvLoader, FlagLoader) return their inner loader's
+	// paths; StaticLoader, which reads nothing from disk, returns nil.
+	Paths() []string
+
+	// Load resolves the config. Implementations that wrap another Loader
+	// call it first and layer their own overrides on top of the result.
+	Load(ctx context.Context) (*common.Config, error)
+}
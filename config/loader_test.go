@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTempConfig(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "mu-config-*.yml")
+	assert.Nil(t, err)
+	_, err = file.WriteString(contents)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	return file.Name()
+}
+
+func TestFileLoader_Merge(t *testing.T) {
+	assert := assert.New(t)
+
+	base := writeTempConfig(t, `
+---
+environments:
+  - name: dev
+    cluster:
+      instanceType: t2.micro
+`)
+	defer os.Remove(base)
+
+	override := writeTempConfig(t, `
+---
+environments:
+  - name: dev
+    cluster:
+      instanceType: t2.small
+`)
+	defer os.Remove(override)
+
+	loader := NewFileLoader(base, override, "/does/not/exist.yml")
+	assert.Equal([]string{base, override, "/does/not/exist.yml"}, loader.Paths())
+
+	config, err := loader.Load(context.Background())
+	assert.Nil(err)
+	assert.Equal("t2.small", config.Environments[0].Cluster.InstanceType)
+}
+
+func TestFileLoader_MergeDistinctEnvironments(t *testing.T) {
+	assert := assert.New(t)
+
+	base := writeTempConfig(t, `
+---
+environments:
+  - name: dev
+    cluster:
+      instanceType: t2.micro
+  - name: staging
+    cluster:
+      instanceType: t2.medium
+  - name: prod
+    cluster:
+      instanceType: m4.large
+`)
+	defer os.Remove(base)
+
+	override := writeTempConfig(t, `
+---
+environments:
+  - name: dev
+    cluster:
+      desiredSize: 2
+`)
+	defer os.Remove(override)
+
+	loader := NewFileLoader(base, override)
+	config, err := loader.Load(context.Background())
+	assert.Nil(err)
+
+	assert.Len(config.Environments, 3)
+	assert.Equal("t2.micro", config.Environments[0].Cluster.InstanceType)
+	assert.Equal(2, config.Environments[0].Cluster.DesiredSize)
+	assert.Equal("t2.medium", config.Environments[1].Cluster.InstanceType)
+	assert.Equal("m4.large", config.Environments[2].Cluster.InstanceType)
+}
+
+func TestFileLoader_NoneFound(t *testing.T) {
+	assert := assert.New(t)
+
+	loader := NewFileLoader("/does/not/exist.yml")
+	_, err := loader.Load(context.Background())
+	assert.NotNil(err)
+}
+
+func TestEnvLoader(t *testing.T) {
+	assert := assert.New(t)
+
+	base := writeTempConfig(t, `
+---
+environments:
+  - name: dev
+    cluster:
+      instanceType: t2.micro
+`)
+	defer os.Remove(base)
+
+	os.Setenv("MU_ENV_DEV_CLUSTER_INSTANCE_TYPE", "m4.large")
+	os.Setenv("MU_PARAM_DesiredCapacity", "3")
+	defer os.Unsetenv("MU_ENV_DEV_CLUSTER_INSTANCE_TYPE")
+	defer os.Unsetenv("MU_PARAM_DesiredCapacity")
+
+	loader := NewEnvLoader(NewFileLoader(base))
+	config, err := loader.Load(context.Background())
+	assert.Nil(err)
+	assert.Equal("m4.large", config.Environments[0].Cluster.InstanceType)
+	assert.Equal("3", config.Parameters["DesiredCapacity"])
+}
+
+func TestFlagLoader(t *testing.T) {
+	assert := assert.New(t)
+
+	loader := NewFlagLoader(NewStaticLoader(new(common.Config)), []string{"DesiredCapacity=5", "KeyName=my-key"})
+	config, err := loader.Load(context.Background())
+	assert.Nil(err)
+	assert.Equal("5", config.Parameters["DesiredCapacity"])
+	assert.Equal("my-key", config.Parameters["KeyName"])
+}
+
+func TestFlagLoader_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	loader := NewFlagLoader(NewStaticLoader(new(common.Config)), []string{"NoEquals"})
+	_, err := loader.Load(context.Background())
+	assert.NotNil(err)
+}
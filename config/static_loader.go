@@ -0,0 +1,29 @@
+package config
+
+import (
+	"context"
+
+	"github.com/stelligent/mu/common"
+)
+
+// StaticLoader wraps an already-resolved common.Config. It's useful for
+// tests, and as the base Loader for callers that build up a Config in
+// memory rather than from files.
+type StaticLoader struct {
+	config *common.Config
+}
+
+// NewStaticLoader creates a Loader that always resolves to config.
+func NewStaticLoader(config *common.Config) *StaticLoader {
+	return &StaticLoader{config: config}
+}
+
+// Paths always returns nil; a StaticLoader reads no files.
+func (loader *StaticLoader) Paths() []string {
+	return nil
+}
+
+// Load returns the wrapped config.
+func (loader *StaticLoader) Load(ctx context.Context) (*common.Config, error) {
+	return loader.config, nil
+}
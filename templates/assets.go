@@ -0,0 +1,184 @@
+package templates
+
+const vpcTemplate = `
+AWSTemplateFormatVersion: '2010-09-09'
+Description: MU VPC
+Parameters:
+  VpcCidr:
+    Type: String
+    Default: 10.0.0.0/16
+Resources:
+  Vpc:
+    Type: AWS::EC2::VPC
+    Properties:
+      CidrBlock: !Ref VpcCidr
+  PublicSubnetAZ1:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [0, !GetAZs '']
+  PublicSubnetAZ2:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [1, !GetAZs '']
+  PublicSubnetAZ3:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [2, !GetAZs '']
+  PrivateSubnetAZ1:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [0, !GetAZs '']
+  PrivateSubnetAZ2:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [1, !GetAZs '']
+  PrivateSubnetAZ3:
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref Vpc
+      AvailabilityZone: !Select [2, !GetAZs '']
+  # NatGatewayAZ1-3 give instances in the private subnets an egress path, but
+  # this template doesn't yet own any route tables (public or private) to
+  # point at them or at an internet gateway - wiring that up is deferred
+  # until private-subnet egress is actually exercised end-to-end.
+  NatGatewayAZ1:
+    Type: AWS::EC2::NatGateway
+    Properties:
+      SubnetId: !Ref PublicSubnetAZ1
+  NatGatewayAZ2:
+    Type: AWS::EC2::NatGateway
+    Properties:
+      SubnetId: !Ref PublicSubnetAZ2
+  NatGatewayAZ3:
+    Type: AWS::EC2::NatGateway
+    Properties:
+      SubnetId: !Ref PublicSubnetAZ3
+Outputs:
+  VpcId:
+    Value: !Ref Vpc
+  PublicSubnetAZ1Id:
+    Value: !Ref PublicSubnetAZ1
+  PublicSubnetAZ2Id:
+    Value: !Ref PublicSubnetAZ2
+  PublicSubnetAZ3Id:
+    Value: !Ref PublicSubnetAZ3
+  PrivateSubnetAZ1Id:
+    Value: !Ref PrivateSubnetAZ1
+  PrivateSubnetAZ2Id:
+    Value: !Ref PrivateSubnetAZ2
+  PrivateSubnetAZ3Id:
+    Value: !Ref PrivateSubnetAZ3
+  NatGatewayAZ1Id:
+    Value: !Ref NatGatewayAZ1
+  NatGatewayAZ2Id:
+    Value: !Ref NatGatewayAZ2
+  NatGatewayAZ3Id:
+    Value: !Ref NatGatewayAZ3
+`
+
+const clusterTemplate = `
+AWSTemplateFormatVersion: '2010-09-09'
+Description: MU ECS Cluster
+Parameters:
+  VpcId:
+    Type: String
+  InstanceSubnetType:
+    Type: String
+    Default: public
+    AllowedValues:
+      - public
+      - private
+  PublicSubnetAZ1Id:
+    Type: String
+    Default: ''
+  PublicSubnetAZ2Id:
+    Type: String
+    Default: ''
+  PublicSubnetAZ3Id:
+    Type: String
+    Default: ''
+  PrivateSubnetAZ1Id:
+    Type: String
+    Default: ''
+  PrivateSubnetAZ2Id:
+    Type: String
+    Default: ''
+  PrivateSubnetAZ3Id:
+    Type: String
+    Default: ''
+  # NatGatewayAZ1-3Id are accepted so configureUnmanagedVpc (workflows/steps.go)
+  # can pass through a vpcTarget's natGatewayIds, but this cluster stack owns
+  # no route tables for the subnets it's handed - actually routing egress
+  # through them is the responsibility of whoever manages the supplied VPC,
+  # same as for the managed-VPC path (see the note on vpc-template's
+  # NatGatewayAZ resources).
+  NatGatewayAZ1Id:
+    Type: String
+    Default: ''
+  NatGatewayAZ2Id:
+    Type: String
+    Default: ''
+  NatGatewayAZ3Id:
+    Type: String
+    Default: ''
+  ImageID:
+    Type: String
+  MinInstancesPerAZ:
+    Type: String
+    Default: ''
+  MaxInstancesPerAZ:
+    Type: String
+    Default: ''
+  # MinSize/MaxSize are the cluster-wide instance counts applyInstanceDistribution
+  # derives from MinInstancesPerAZ/MaxInstancesPerAZ (per-AZ counts times the
+  # resolved subnet AZ count) - CloudFormation has no arithmetic intrinsic, so
+  # the multiplication happens in Go before the stack is deployed.
+  MinSize:
+    Type: String
+    Default: ''
+  MaxSize:
+    Type: String
+    Default: ''
+  # DistributionGroupInstanceIds is accepted and forwarded by
+  # applyInstanceDistribution (workflows/steps.go) but is not yet wired to
+  # any resource here - real anti-affinity against another environment's
+  # instances would need a custom resource to place this environment's
+  # instances away from them, which is deferred until a concrete use case
+  # justifies it.
+  DistributionGroupInstanceIds:
+    Type: CommaDelimitedList
+    Default: ''
+Conditions:
+  IsPrivateSubnet: !Equals [!Ref InstanceSubnetType, private]
+  HasMinSize: !Not [!Equals [!Ref MinSize, '']]
+  HasMaxSize: !Not [!Equals [!Ref MaxSize, '']]
+Resources:
+  EcsCluster:
+    Type: AWS::ECS::Cluster
+  InstanceLaunchConfiguration:
+    Type: AWS::AutoScaling::LaunchConfiguration
+    Properties:
+      ImageId: !Ref ImageID
+  InstanceAutoScalingGroup:
+    Type: AWS::AutoScaling::AutoScalingGroup
+    Properties:
+      LaunchConfigurationName: !Ref InstanceLaunchConfiguration
+      VPCZoneIdentifier: !If
+        - IsPrivateSubnet
+        - - !Ref PrivateSubnetAZ1Id
+          - !Ref PrivateSubnetAZ2Id
+          - !Ref PrivateSubnetAZ3Id
+        - - !Ref PublicSubnetAZ1Id
+          - !Ref PublicSubnetAZ2Id
+          - !Ref PublicSubnetAZ3Id
+      MinSize: !If [HasMinSize, !Ref MinSize, 1]
+      MaxSize: !If [HasMaxSize, !Ref MaxSize, 1]
+Outputs:
+  EcsClusterName:
+    Value: !Ref EcsCluster
+`
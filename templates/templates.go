@@ -0,0 +1,24 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// assets holds the raw CloudFormation templates mu uses to provision
+// environments, keyed by a file-style name (e.g. "vpc-template.yml"). The
+// template bodies themselves are the string constants in assets.go.
+var assets = map[string]string{
+	"vpc-template.yml":     vpcTemplate,
+	"cluster-template.yml": clusterTemplate,
+}
+
+// GetAsset returns a reader over the named template, or an error if no such
+// template is registered.
+func GetAsset(name string) (*strings.Reader, error) {
+	body, ok := assets[name]
+	if !ok {
+		return nil, fmt.Errorf("unable to find asset for template '%s'", name)
+	}
+	return strings.NewReader(body), nil
+}
@@ -0,0 +1,123 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stelligent/mu/config"
+)
+
+// findEnvironment locates the named environment in config.
+func findEnvironment(cfg *common.Config, environmentName string) (*common.Environment, error) {
+	for i := range cfg.Environments {
+		if cfg.Environments[i].Name == environmentName {
+			return &cfg.Environments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find environment named '%s'", environmentName)
+}
+
+// loadConfigInto resolves loader and assigns the result to ctx.Config.
+func loadConfigInto(ctx *common.Context, loader config.Loader) Executor {
+	return func() error {
+		cfg, err := loader.Load(context.Background())
+		if err != nil {
+			return err
+		}
+		ctx.Config = *cfg
+		return nil
+	}
+}
+
+// runNamedWorkflow resolves the named environment's workflow (falling back
+// to mu's built-in provision/decommission steps if the environment doesn't
+// declare one of that name) and runs it, assuming ctx.Config is already
+// populated.
+func runNamedWorkflow(ctx *common.Context, environmentName string, workflowName string, writer io.Writer, dryRun bool) Executor {
+	return func() error {
+		environment, err := findEnvironment(&ctx.Config, environmentName)
+		if err != nil {
+			return err
+		}
+
+		steps := environment.Workflows[workflowName]
+		if steps == nil {
+			steps = defaultWorkflowSteps[workflowName]
+		}
+		if steps == nil {
+			return fmt.Errorf("environment '%s' has no workflow named '%s'", environmentName, workflowName)
+		}
+
+		wctx := &WorkflowContext{
+			Context:     ctx,
+			Environment: environment,
+			StackParams: make(map[string]string),
+			Writer:      writer,
+			DryRun:      dryRun,
+		}
+		if err := runWorkflowSteps(steps, wctx); err != nil {
+			return err
+		}
+		if wctx.DryRun {
+			return renderChangeSets(wctx)
+		}
+		return nil
+	}
+}
+
+// NewEnvironmentUpserter creates a workflow that resolves config via loader,
+// then runs the named environment's "provision" workflow. When dryRun is
+// true, no stack is actually created or updated: each upsert step plans a
+// change set instead, and the consolidated diff is written to writer.
+func NewEnvironmentUpserter(ctx *common.Context, loader config.Loader, environmentName string, writer io.Writer, dryRun bool) Executor {
+	return newPipelineExecutor(
+		loadConfigInto(ctx, loader),
+		runNamedWorkflow(ctx, environmentName, "provision", writer, dryRun),
+	)
+}
+
+// NewEnvironmentViewer creates a workflow that resolves config via loader,
+// then writes details of the named environment to writer.
+func NewEnvironmentViewer(ctx *common.Context, loader config.Loader, environmentName string, writer io.Writer) Executor {
+	return newPipelineExecutor(
+		loadConfigInto(ctx, loader),
+		func() error {
+			environment, err := findEnvironment(&ctx.Config, environmentName)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(writer, "environment: %s\n", environment.Name)
+			return err
+		},
+	)
+}
+
+// NewEnvironmentLister creates a workflow that writes the name of every
+// configured environment to writer.
+func NewEnvironmentLister(ctx *common.Context, writer io.Writer) Executor {
+	return func() error {
+		for _, environment := range ctx.Config.Environments {
+			if _, err := fmt.Fprintf(writer, "%s\n", environment.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// NewEnvironmentTerminator creates a workflow that runs the named
+// environment's "decommission" workflow. When dryRun is true, no stack is
+// actually deleted: each terminate step instead reports the stack it would
+// delete, and the consolidated report is written to writer.
+func NewEnvironmentTerminator(ctx *common.Context, environmentName string, writer io.Writer, dryRun bool) Executor {
+	return runNamedWorkflow(ctx, environmentName, "decommission", writer, dryRun)
+}
+
+// NewEnvironmentWorkflowRunner creates a workflow that runs an arbitrary,
+// user-defined workflow (e.g. "rotate") declared on the named environment,
+// assuming ctx.Config is already populated.
+func NewEnvironmentWorkflowRunner(ctx *common.Context, environmentName string, workflowName string) Executor {
+	return runNamedWorkflow(ctx, environmentName, workflowName, nil, false)
+}
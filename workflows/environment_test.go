@@ -2,58 +2,97 @@ package workflows
 
 import (
 	"bytes"
+	"io"
+	"testing"
+
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/stelligent/mu/common"
+	"github.com/stelligent/mu/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gopkg.in/yaml.v2"
-	"io"
-	"testing"
 )
 
-func TestEnvironmentFinder(t *testing.T) {
-	assert := assert.New(t)
+type mockedStackManager struct {
+	mock.Mock
+}
 
-	env1 := common.Environment{
-		Name: "foo",
-	}
-	env2 := common.Environment{
-		Name: "bar",
+func (m *mockedStackManager) AwaitFinalStatus(stackName string) string {
+	args := m.Called(stackName)
+	return args.String(0)
+}
+func (m *mockedStackManager) UpsertStack(stackName string, templateBodyReader io.Reader, stackParameters map[string]string, stackTags map[string]string) error {
+	args := m.Called(stackName)
+	return args.Error(0)
+}
+func (m *mockedStackManager) DeleteStack(stackName string) error {
+	args := m.Called(stackName)
+	return args.Error(0)
+}
+func (m *mockedStackManager) FindLatestImageID(pattern string) (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+func (m *mockedStackManager) ListStackInstances(stackName string) ([]string, error) {
+	args := m.Called(stackName)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockedStackManager) ListRetainedResources(stackName string) ([]string, error) {
+	args := m.Called(stackName)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockedStackManager) CreateChangeSet(stackName string, templateBodyReader io.Reader, stackParameters map[string]string, stackTags map[string]string) (*common.ChangeSetSummary, error) {
+	args := m.Called(stackName)
+	return args.Get(0).(*common.ChangeSetSummary), args.Error(1)
+}
+
+func loadYamlConfig(yamlString string) (*common.Config, error) {
+	cfg := new(common.Config)
+	yamlBuffer := new(bytes.Buffer)
+	yamlBuffer.ReadFrom(bytes.NewBufferString(yamlString))
+	err := yaml.Unmarshal(yamlBuffer.Bytes(), cfg)
+	if err != nil {
+		return nil, err
 	}
-	config := new(common.Config)
-	config.Environments = []common.Environment{env1, env2}
 
-	workflow := new(environmentWorkflow)
+	return cfg, nil
+}
+
+func TestFindEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := new(common.Config)
+	cfg.Environments = []common.Environment{
+		{Name: "foo"},
+		{Name: "bar"},
+	}
 
-	workflow.environment = nil
-	fooErr := workflow.environmentFinder(config, "foo")()
-	assert.NotNil(workflow.environment)
-	assert.Equal("foo", workflow.environment.Name)
-	assert.Nil(fooErr)
+	foo, err := findEnvironment(cfg, "foo")
+	assert.Nil(err)
+	assert.Equal("foo", foo.Name)
 
-	workflow.environment = nil
-	barErr := workflow.environmentFinder(config, "bar")()
-	assert.NotNil(workflow.environment)
-	assert.Equal("bar", workflow.environment.Name)
-	assert.Nil(barErr)
+	bar, err := findEnvironment(cfg, "bar")
+	assert.Nil(err)
+	assert.Equal("bar", bar.Name)
 
-	workflow.environment = nil
-	bazErr := workflow.environmentFinder(config, "baz")()
-	assert.Nil(workflow.environment)
-	assert.NotNil(bazErr)
+	baz, err := findEnvironment(cfg, "baz")
+	assert.Nil(baz)
+	assert.NotNil(err)
 }
 
 func TestNewEnvironmentUpserter(t *testing.T) {
 	assert := assert.New(t)
 	ctx := common.NewContext()
-	upserter := NewEnvironmentUpserter(ctx, "foo")
+	loader := config.NewStaticLoader(new(common.Config))
+	upserter := NewEnvironmentUpserter(ctx, loader, "foo", nil, false)
 	assert.NotNil(upserter)
 }
 
 func TestNewEnvironmentViewer(t *testing.T) {
 	assert := assert.New(t)
 	ctx := common.NewContext()
-	viewer := NewEnvironmentViewer(ctx, "foo", nil)
+	loader := config.NewStaticLoader(new(common.Config))
+	viewer := NewEnvironmentViewer(ctx, loader, "foo", nil)
 	assert.NotNil(viewer)
 }
 
@@ -67,81 +106,126 @@ func TestNewEnvironmentLister(t *testing.T) {
 func TestNewEnvironmentTerminator(t *testing.T) {
 	assert := assert.New(t)
 	ctx := common.NewContext()
-	terminator := NewEnvironmentTerminator(ctx, "foo")
+	terminator := NewEnvironmentTerminator(ctx, "foo", nil, false)
 	assert.NotNil(terminator)
 }
 
-type mockedStackManager struct {
-	mock.Mock
+func TestNewEnvironmentWorkflowRunner(t *testing.T) {
+	assert := assert.New(t)
+	ctx := common.NewContext()
+	runner := NewEnvironmentWorkflowRunner(ctx, "foo", "rotate")
+	assert.NotNil(runner)
 }
 
-func (m *mockedStackManager) AwaitFinalStatus(stackName string) string {
-	args := m.Called(stackName)
-	return args.String(0)
-}
-func (m *mockedStackManager) UpsertStack(stackName string, templateBodyReader io.Reader, stackParameters map[string]string, stackTags map[string]string) error {
-	args := m.Called(stackName)
-	return args.Error(0)
-}
-func (m *mockedStackManager) DeleteStack(stackName string) error {
-	args := m.Called(stackName)
-	return args.Error(0)
+func newTestWorkflowContext(environment *common.Environment, stackManager common.StackManager) *WorkflowContext {
+	return &WorkflowContext{
+		Context:     &common.Context{StackManager: stackManager},
+		Environment: environment,
+		StackParams: make(map[string]string),
+	}
 }
-func (m *mockedStackManager) FindLatestImageID(pattern string) (string, error) {
-	args := m.Called()
-	return args.String(0), args.Error(1)
+
+func TestEcsUpsertStep(t *testing.T) {
+	assert := assert.New(t)
+
+	environment := &common.Environment{Name: "foo"}
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("AwaitFinalStatus", "mu-cluster-foo").Return(cloudformation.StackStatusCreateComplete)
+	stackManager.On("UpsertStack", "mu-cluster-foo").Return(nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+
+	wctx := newTestWorkflowContext(environment, stackManager)
+	err := new(ecsUpsertStep).Run(wctx)
+	assert.Nil(err)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 1)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 1)
 }
 
-func TestEnvironmentEcsUpserter(t *testing.T) {
+func TestEcsUpsertStep_SpreadAZ(t *testing.T) {
 	assert := assert.New(t)
 
-	workflow := new(environmentWorkflow)
-	workflow.environment = &common.Environment{
+	environment := &common.Environment{
 		Name: "foo",
+		Cluster: common.Cluster{
+			InstanceDistribution: "spread-az",
+			DesiredSize:          3,
+			MaxSize:              6,
+		},
 	}
 
-	vpcInputParams := make(map[string]string)
-
 	stackManager := new(mockedStackManager)
 	stackManager.On("AwaitFinalStatus", "mu-cluster-foo").Return(cloudformation.StackStatusCreateComplete)
 	stackManager.On("UpsertStack", "mu-cluster-foo").Return(nil)
 	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
 
-	err := workflow.environmentEcsUpserter(vpcInputParams, stackManager, stackManager, stackManager)()
+	wctx := newTestWorkflowContext(environment, stackManager)
+	wctx.StackParams["InstanceSubnetType"] = "public"
+	wctx.StackParams["PublicSubnetAZ1Id"] = "subnet-1"
+	wctx.StackParams["PublicSubnetAZ2Id"] = "subnet-2"
+	wctx.StackParams["PublicSubnetAZ3Id"] = "subnet-3"
+
+	err := new(ecsUpsertStep).Run(wctx)
 	assert.Nil(err)
+	assert.Equal("1", wctx.StackParams["MinInstancesPerAZ"])
+	assert.Equal("2", wctx.StackParams["MaxInstancesPerAZ"])
+	assert.Equal("3", wctx.StackParams["MinSize"])
+	assert.Equal("6", wctx.StackParams["MaxSize"])
 
 	stackManager.AssertExpectations(t)
-	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 1)
-	stackManager.AssertNumberOfCalls(t, "UpsertStack", 1)
+	stackManager.AssertNumberOfCalls(t, "ListStackInstances", 0)
 }
 
-func TestEnvironmentVpcUpserter(t *testing.T) {
+func TestEcsUpsertStep_DistributionGroup(t *testing.T) {
 	assert := assert.New(t)
 
-	workflow := new(environmentWorkflow)
-	workflow.environment = &common.Environment{
+	environment := &common.Environment{
 		Name: "foo",
+		Cluster: common.Cluster{
+			InstanceDistribution: "distribution-group:prod",
+		},
 	}
 
-	vpcInputParams := make(map[string]string)
+	stackManager := new(mockedStackManager)
+	stackManager.On("AwaitFinalStatus", "mu-cluster-foo").Return(cloudformation.StackStatusCreateComplete)
+	stackManager.On("UpsertStack", "mu-cluster-foo").Return(nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+	stackManager.On("ListStackInstances", "mu-cluster-prod").Return([]string{"i-1", "i-2"}, nil)
+
+	wctx := newTestWorkflowContext(environment, stackManager)
+	err := new(ecsUpsertStep).Run(wctx)
+	assert.Nil(err)
+	assert.Equal("i-1,i-2", wctx.StackParams["DistributionGroupInstanceIds"])
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "ListStackInstances", 1)
+}
+
+func TestVpcUpsertStep(t *testing.T) {
+	assert := assert.New(t)
+
+	environment := &common.Environment{Name: "foo"}
 
 	stackManager := new(mockedStackManager)
 	stackManager.On("AwaitFinalStatus", "mu-vpc-foo").Return(cloudformation.StackStatusCreateComplete)
 	stackManager.On("UpsertStack", "mu-vpc-foo").Return(nil)
 
-	err := workflow.environmentVpcUpserter(vpcInputParams, stackManager, stackManager)()
+	wctx := newTestWorkflowContext(environment, stackManager)
+	err := new(vpcUpsertStep).Run(wctx)
 	assert.Nil(err)
-	assert.Equal("mu-vpc-foo-VpcId", vpcInputParams["VpcId"])
-	assert.Equal("mu-vpc-foo-PublicSubnetAZ1Id", vpcInputParams["PublicSubnetAZ1Id"])
-	assert.Equal("mu-vpc-foo-PublicSubnetAZ2Id", vpcInputParams["PublicSubnetAZ2Id"])
-	assert.Equal("mu-vpc-foo-PublicSubnetAZ3Id", vpcInputParams["PublicSubnetAZ3Id"])
+	assert.Equal("mu-vpc-foo-VpcId", wctx.StackParams["VpcId"])
+	assert.Equal("mu-vpc-foo-PublicSubnetAZ1Id", wctx.StackParams["PublicSubnetAZ1Id"])
+	assert.Equal("mu-vpc-foo-PublicSubnetAZ2Id", wctx.StackParams["PublicSubnetAZ2Id"])
+	assert.Equal("mu-vpc-foo-PublicSubnetAZ3Id", wctx.StackParams["PublicSubnetAZ3Id"])
 
 	stackManager.AssertExpectations(t)
 	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 1)
 	stackManager.AssertNumberOfCalls(t, "UpsertStack", 1)
 }
 
-func TestEnvironmentVpcUpserter_Unmanaged(t *testing.T) {
+func TestVpcUpsertStep_Unmanaged(t *testing.T) {
 	assert := assert.New(t)
 	yamlConfig :=
 		`
@@ -154,52 +238,104 @@ environments:
         - mySubnetId1
         - mySubnetId2
 `
-	config, err := loadYamlConfig(yamlConfig)
+	cfg, err := loadYamlConfig(yamlConfig)
 	assert.Nil(err)
 
-	vpcInputParams := make(map[string]string)
-
 	stackManager := new(mockedStackManager)
+	wctx := newTestWorkflowContext(&cfg.Environments[0], stackManager)
+
+	err = new(vpcUpsertStep).Run(wctx)
+	assert.Nil(err)
+	assert.Equal("myVpcId", wctx.StackParams["VpcId"])
+	assert.Equal("mySubnetId1", wctx.StackParams["PublicSubnetAZ1Id"])
+	assert.Equal("mySubnetId2", wctx.StackParams["PublicSubnetAZ2Id"])
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 0)
+}
+
+func TestVpcUpsertStep_UnmanagedPrivate(t *testing.T) {
+	assert := assert.New(t)
+	yamlConfig :=
+		`
+---
+environments:
+  - name: dev
+    vpcTarget:
+      vpcId: myVpcId
+      instanceSubnetType: private
+      publicSubnetIds:
+        - mySubnetId1
+        - mySubnetId2
+      privateSubnetIds:
+        - myPrivateSubnetId1
+        - myPrivateSubnetId2
+      natGatewayIds:
+        - myNatGatewayId1
+        - myNatGatewayId2
+`
+	cfg, err := loadYamlConfig(yamlConfig)
+	assert.Nil(err)
 
-	workflow := new(environmentWorkflow)
-	workflow.environment = &config.Environments[0]
+	stackManager := new(mockedStackManager)
+	wctx := newTestWorkflowContext(&cfg.Environments[0], stackManager)
 
-	err = workflow.environmentVpcUpserter(vpcInputParams, stackManager, stackManager)()
+	err = new(vpcUpsertStep).Run(wctx)
 	assert.Nil(err)
-	assert.Equal("myVpcId", vpcInputParams["VpcId"])
-	assert.Equal("mySubnetId1", vpcInputParams["PublicSubnetAZ1Id"])
-	assert.Equal("mySubnetId2", vpcInputParams["PublicSubnetAZ2Id"])
+	assert.Equal("myVpcId", wctx.StackParams["VpcId"])
+	assert.Equal("private", wctx.StackParams["InstanceSubnetType"])
+	assert.Equal("myPrivateSubnetId1", wctx.StackParams["PrivateSubnetAZ1Id"])
+	assert.Equal("myPrivateSubnetId2", wctx.StackParams["PrivateSubnetAZ2Id"])
+	assert.Equal("myNatGatewayId1", wctx.StackParams["NatGatewayAZ1Id"])
+	assert.Equal("myNatGatewayId2", wctx.StackParams["NatGatewayAZ2Id"])
 
 	stackManager.AssertExpectations(t)
 	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
 	stackManager.AssertNumberOfCalls(t, "UpsertStack", 0)
 }
 
-func loadYamlConfig(yamlString string) (*common.Config, error) {
-	config := new(common.Config)
-	yamlBuffer := new(bytes.Buffer)
-	yamlBuffer.ReadFrom(bytes.NewBufferString(yamlString))
-	err := yaml.Unmarshal(yamlBuffer.Bytes(), config)
-	if err != nil {
-		return nil, err
-	}
+func TestVpcUpsertStep_UnmanagedInsufficientAZs(t *testing.T) {
+	assert := assert.New(t)
+	yamlConfig :=
+		`
+---
+environments:
+  - name: dev
+    vpcTarget:
+      vpcId: myVpcId
+      instanceSubnetType: private
+      publicSubnetIds:
+        - mySubnetId1
+        - mySubnetId2
+      privateSubnetIds:
+        - myPrivateSubnetId1
+`
+	cfg, err := loadYamlConfig(yamlConfig)
+	assert.Nil(err)
+
+	stackManager := new(mockedStackManager)
+	wctx := newTestWorkflowContext(&cfg.Environments[0], stackManager)
 
-	return config, nil
+	err = new(vpcUpsertStep).Run(wctx)
+	assert.NotNil(err)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 0)
 }
 
-func TestNewEnvironmentEcsTerminator(t *testing.T) {
+func TestEcsTerminateStep(t *testing.T) {
 	assert := assert.New(t)
 
-	workflow := new(environmentWorkflow)
-	workflow.environment = &common.Environment{
-		Name: "foo",
-	}
+	environment := &common.Environment{Name: "foo"}
 
 	stackManager := new(mockedStackManager)
 	stackManager.On("AwaitFinalStatus", "mu-cluster-foo").Return(cloudformation.StackStatusDeleteComplete)
 	stackManager.On("DeleteStack", "mu-cluster-foo").Return(nil)
 
-	err := workflow.environmentEcsTerminator("foo", stackManager, stackManager)()
+	wctx := newTestWorkflowContext(environment, stackManager)
+	err := new(ecsTerminateStep).Run(wctx)
 	assert.Nil(err)
 
 	stackManager.AssertExpectations(t)
@@ -207,22 +343,165 @@ func TestNewEnvironmentEcsTerminator(t *testing.T) {
 	stackManager.AssertNumberOfCalls(t, "DeleteStack", 1)
 }
 
-func TestNewEnvironmentVpcTerminator(t *testing.T) {
+func TestVpcTerminateStep(t *testing.T) {
 	assert := assert.New(t)
 
-	workflow := new(environmentWorkflow)
-	workflow.environment = &common.Environment{
-		Name: "foo",
-	}
+	environment := &common.Environment{Name: "foo"}
 
 	stackManager := new(mockedStackManager)
 	stackManager.On("AwaitFinalStatus", "mu-vpc-foo").Return(cloudformation.StackStatusDeleteComplete)
 	stackManager.On("DeleteStack", "mu-vpc-foo").Return(nil)
 
-	err := workflow.environmentVpcTerminator("foo", stackManager, stackManager)()
+	wctx := newTestWorkflowContext(environment, stackManager)
+	err := new(vpcTerminateStep).Run(wctx)
 	assert.Nil(err)
 
 	stackManager.AssertExpectations(t)
 	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 1)
 	stackManager.AssertNumberOfCalls(t, "DeleteStack", 1)
-}
\ No newline at end of file
+}
+
+func TestRunWorkflowSteps_UnknownStepType(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManager := new(mockedStackManager)
+	wctx := newTestWorkflowContext(&common.Environment{Name: "foo"}, stackManager)
+
+	err := runWorkflowSteps([]common.WorkflowStep{{Type: "does-not-exist"}}, wctx)
+	assert.NotNil(err)
+}
+
+func TestRunWorkflowSteps_PreAndPostHooks(t *testing.T) {
+	assert := assert.New(t)
+
+	environment := &common.Environment{Name: "foo"}
+	stackManager := new(mockedStackManager)
+	stackManager.On("AwaitFinalStatus", "mu-vpc-foo").Return(cloudformation.StackStatusCreateComplete)
+	stackManager.On("UpsertStack", "mu-vpc-foo").Return(nil)
+
+	wctx := newTestWorkflowContext(environment, stackManager)
+
+	steps := []common.WorkflowStep{
+		{
+			Type: "vpc-upsert",
+			Pre:  []common.WorkflowStep{{Type: "run-shell", Params: map[string]string{"command": "true"}}},
+			Post: []common.WorkflowStep{{Type: "run-shell", Params: map[string]string{"command": "true"}}},
+		},
+	}
+
+	err := runWorkflowSteps(steps, wctx)
+	assert.Nil(err)
+	assert.Equal("mu-vpc-foo-VpcId", wctx.StackParams["VpcId"])
+}
+
+func TestWaitStackStep(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("AwaitFinalStatus", "mu-vpc-foo").Return(cloudformation.StackStatusCreateComplete)
+
+	wctx := newTestWorkflowContext(&common.Environment{Name: "foo"}, stackManager)
+	step := &waitStackStep{params: map[string]string{"stackName": "mu-vpc-foo"}}
+	assert.Nil(step.Run(wctx))
+}
+
+func TestWaitStackStep_RollbackFails(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("AwaitFinalStatus", "mu-vpc-foo").Return(cloudformation.StackStatusRollbackComplete)
+
+	wctx := newTestWorkflowContext(&common.Environment{Name: "foo"}, stackManager)
+	step := &waitStackStep{params: map[string]string{"stackName": "mu-vpc-foo"}}
+	assert.NotNil(step.Run(wctx))
+}
+
+func TestRunShellStep_RequiresCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	wctx := newTestWorkflowContext(&common.Environment{Name: "foo"}, new(mockedStackManager))
+	step := &runShellStep{params: map[string]string{}}
+	assert.NotNil(step.Run(wctx))
+}
+
+func TestRunShellStep_DryRunSkips(t *testing.T) {
+	assert := assert.New(t)
+
+	wctx := newTestWorkflowContext(&common.Environment{Name: "foo"}, new(mockedStackManager))
+	wctx.DryRun = true
+	step := &runShellStep{params: map[string]string{"command": "rm -rf /tmp/should-not-run"}}
+
+	err := step.Run(wctx)
+	assert.Nil(err)
+	assert.Len(wctx.ChangeSets, 1)
+	assert.Equal("SKIP-SHELL", wctx.ChangeSets[0].Action)
+	assert.Equal("rm -rf /tmp/should-not-run", wctx.ChangeSets[0].StackName)
+}
+
+func TestVpcUpsertStep_DryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	environment := &common.Environment{Name: "foo"}
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("CreateChangeSet", "mu-vpc-foo").Return(&common.ChangeSetSummary{StackName: "mu-vpc-foo", Action: "CREATE"}, nil)
+
+	wctx := newTestWorkflowContext(environment, stackManager)
+	wctx.DryRun = true
+
+	err := new(vpcUpsertStep).Run(wctx)
+	assert.Nil(err)
+	assert.Len(wctx.ChangeSets, 1)
+	assert.Equal("mu-vpc-foo", wctx.ChangeSets[0].StackName)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 0)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
+}
+
+func TestEcsTerminateStep_DryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	environment := &common.Environment{Name: "foo"}
+	stackManager := new(mockedStackManager)
+	stackManager.On("ListRetainedResources", "mu-cluster-foo").Return([]string{"ImportantBucket"}, nil)
+	wctx := newTestWorkflowContext(environment, stackManager)
+	wctx.DryRun = true
+
+	err := new(ecsTerminateStep).Run(wctx)
+	assert.Nil(err)
+	assert.Len(wctx.ChangeSets, 1)
+	assert.Equal("mu-cluster-foo", wctx.ChangeSets[0].StackName)
+	assert.Equal("DELETE", wctx.ChangeSets[0].Action)
+	assert.Equal([]string{"ImportantBucket"}, wctx.ChangeSets[0].RetainedResources)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "DeleteStack", 0)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
+}
+
+func TestNewEnvironmentUpserter_DryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := &common.Config{Environments: []common.Environment{{Name: "foo"}}}
+	loader := config.NewStaticLoader(cfg)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("CreateChangeSet", "mu-vpc-foo").Return(&common.ChangeSetSummary{StackName: "mu-vpc-foo", Action: "CREATE"}, nil)
+	stackManager.On("CreateChangeSet", "mu-cluster-foo").Return(&common.ChangeSetSummary{StackName: "mu-cluster-foo", Action: "CREATE"}, nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+
+	ctx := &common.Context{StackManager: stackManager}
+	writer := new(bytes.Buffer)
+
+	upserter := NewEnvironmentUpserter(ctx, loader, "foo", writer, true)
+	err := upserter()
+	assert.Nil(err)
+	assert.Contains(writer.String(), "CREATE mu-vpc-foo")
+	assert.Contains(writer.String(), "CREATE mu-cluster-foo")
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 0)
+	stackManager.AssertNumberOfCalls(t, "DeleteStack", 0)
+	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 0)
+}
@@ -0,0 +1,369 @@
+package workflows
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stelligent/mu/templates"
+)
+
+// upsertStack creates/updates stackName, or, in dry-run mode, plans the
+// change via CreateChangeSet and records the summary instead.
+func upsertStack(wctx *WorkflowContext, stackName string, templateBody io.Reader, params map[string]string, tags map[string]string) error {
+	stackManager := wctx.Context.StackManager
+
+	if wctx.DryRun {
+		summary, err := stackManager.CreateChangeSet(stackName, templateBody, params, tags)
+		if err != nil {
+			return err
+		}
+		wctx.ChangeSets = append(wctx.ChangeSets, summary)
+		return nil
+	}
+
+	if err := stackManager.UpsertStack(stackName, templateBody, params, tags); err != nil {
+		return err
+	}
+	stackManager.AwaitFinalStatus(stackName)
+	return nil
+}
+
+// terminateStack deletes stackName, or, in dry-run mode, records that it
+// would be deleted instead, along with any resources a real delete would
+// retain (DeletionPolicy: Retain) so the plan doesn't read as a clean wipe.
+func terminateStack(wctx *WorkflowContext, stackName string) error {
+	stackManager := wctx.Context.StackManager
+
+	if wctx.DryRun {
+		retainedResources, err := stackManager.ListRetainedResources(stackName)
+		if err != nil {
+			return err
+		}
+		wctx.ChangeSets = append(wctx.ChangeSets, &common.ChangeSetSummary{
+			StackName:         stackName,
+			Action:            "DELETE",
+			RetainedResources: retainedResources,
+		})
+		return nil
+	}
+
+	if err := stackManager.DeleteStack(stackName); err != nil {
+		return err
+	}
+	stackManager.AwaitFinalStatus(stackName)
+	return nil
+}
+
+// vpcUpsertStep creates/updates the `mu-vpc-<environment>` stack, or if the
+// environment declares a vpcTarget, validates and reuses the supplied VPC
+// instead. Either way it populates wctx.StackParams with the parameters the
+// ECS cluster stack needs to reference the VPC's subnets.
+type vpcUpsertStep struct{}
+
+func (step *vpcUpsertStep) Name() string { return "vpc-upsert" }
+
+func (step *vpcUpsertStep) Run(wctx *WorkflowContext) error {
+	environment := wctx.Environment
+	vpcStackName := fmt.Sprintf("mu-vpc-%s", environment.Name)
+
+	if environment.VpcTarget.VpcID != "" {
+		if err := configureUnmanagedVpc(environment, wctx.StackParams); err != nil {
+			return err
+		}
+		applyParamOverrides(wctx, wctx.StackParams)
+		return nil
+	}
+
+	templateBody, err := templates.GetAsset("vpc-template.yml")
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		"mu-type":        "vpc",
+		"mu-environment": environment.Name,
+	}
+	if err := upsertStack(wctx, vpcStackName, templateBody, map[string]string{}, tags); err != nil {
+		return err
+	}
+
+	wctx.StackParams["VpcId"] = fmt.Sprintf("%s-VpcId", vpcStackName)
+	wctx.StackParams["InstanceSubnetType"] = "public"
+	for i := 1; i <= 3; i++ {
+		wctx.StackParams[fmt.Sprintf("PublicSubnetAZ%dId", i)] = fmt.Sprintf("%s-PublicSubnetAZ%dId", vpcStackName, i)
+		wctx.StackParams[fmt.Sprintf("PrivateSubnetAZ%dId", i)] = fmt.Sprintf("%s-PrivateSubnetAZ%dId", vpcStackName, i)
+	}
+	applyParamOverrides(wctx, wctx.StackParams)
+
+	return nil
+}
+
+// configureUnmanagedVpc populates stackParams from a user-supplied
+// vpcTarget instead of creating a VPC stack. instanceSubnetType selects
+// whether the ECS cluster's instances land in the public or private subnets;
+// it defaults to public for backwards compatibility with environments that
+// only specify publicSubnetIds. natGatewayIds is passed through as-is; mu
+// doesn't own route tables in an unmanaged VPC, so wiring actual egress
+// through them is left to whoever manages the target VPC.
+func configureUnmanagedVpc(environment *common.Environment, stackParams map[string]string) error {
+	vpcTarget := environment.VpcTarget
+
+	subnetType := vpcTarget.InstanceSubnetType
+	if subnetType == "" {
+		subnetType = "public"
+	}
+
+	targetSubnetIds := vpcTarget.PublicSubnetIds
+	if subnetType == "private" {
+		targetSubnetIds = vpcTarget.PrivateSubnetIds
+	}
+	if len(targetSubnetIds) < 2 {
+		return fmt.Errorf("vpcTarget must supply at least 2 %s subnet AZs to launch ECS instances into, found %d", subnetType, len(targetSubnetIds))
+	}
+
+	stackParams["VpcId"] = vpcTarget.VpcID
+	stackParams["InstanceSubnetType"] = subnetType
+
+	for i, subnetID := range vpcTarget.PublicSubnetIds {
+		stackParams[fmt.Sprintf("PublicSubnetAZ%dId", i+1)] = subnetID
+	}
+	for i, subnetID := range vpcTarget.PrivateSubnetIds {
+		stackParams[fmt.Sprintf("PrivateSubnetAZ%dId", i+1)] = subnetID
+	}
+	for i, natGatewayID := range vpcTarget.NatGatewayIds {
+		stackParams[fmt.Sprintf("NatGatewayAZ%dId", i+1)] = natGatewayID
+	}
+
+	return nil
+}
+
+// ecsUpsertStep creates/updates the `mu-cluster-<environment>` stack that
+// hosts the environment's ECS container instances, reusing the subnet
+// parameters populated by vpcUpsertStep.
+type ecsUpsertStep struct{}
+
+func (step *ecsUpsertStep) Name() string { return "ecs-upsert" }
+
+func (step *ecsUpsertStep) Run(wctx *WorkflowContext) error {
+	environment := wctx.Environment
+	stackManager := wctx.Context.StackManager
+	clusterStackName := fmt.Sprintf("mu-cluster-%s", environment.Name)
+
+	imageID := environment.Cluster.ImageID
+	if imageID == "" {
+		foundImageID, err := stackManager.FindLatestImageID("amzn-ami*amazon-ecs-optimized")
+		if err != nil {
+			return err
+		}
+		imageID = foundImageID
+	}
+
+	templateBody, err := templates.GetAsset("cluster-template.yml")
+	if err != nil {
+		return err
+	}
+
+	if err := applyInstanceDistribution(environment, wctx.StackParams, stackManager); err != nil {
+		return err
+	}
+
+	clusterParams := make(map[string]string, len(wctx.StackParams)+1)
+	for k, v := range wctx.StackParams {
+		clusterParams[k] = v
+	}
+	clusterParams["ImageID"] = imageID
+
+	applyParamOverrides(wctx, clusterParams)
+
+	tags := map[string]string{
+		"mu-type":        "cluster",
+		"mu-environment": environment.Name,
+	}
+	return upsertStack(wctx, clusterStackName, templateBody, clusterParams, tags)
+}
+
+// distributionGroupPrefix marks an instanceDistribution value that steers
+// this environment's instances away from AZs another named environment has
+// already saturated, e.g. "distribution-group:prod".
+const distributionGroupPrefix = "distribution-group:"
+
+// applyInstanceDistribution translates the environment's instanceDistribution
+// policy into ASG/placement parameters for the cluster stack. "spread-az"
+// computes per-AZ min/max sizes from the subnet AZ count already present in
+// clusterParams and multiplies them out into the MinSize/MaxSize parameters
+// that actually size the cluster's ASG; "distribution-group:<env>" looks up
+// <env>'s current cluster instances and forwards them as
+// DistributionGroupInstanceIds, an anti-affinity hint the cluster template
+// doesn't yet act on; "pack" and the unset default leave instance placement
+// entirely to the ASG.
+func applyInstanceDistribution(environment *common.Environment, clusterParams map[string]string, instanceLister common.StackManager) error {
+	distribution := environment.Cluster.InstanceDistribution
+	if distribution == "" || distribution == "pack" {
+		return nil
+	}
+
+	if strings.HasPrefix(distribution, distributionGroupPrefix) {
+		groupEnvironment := strings.TrimPrefix(distribution, distributionGroupPrefix)
+		groupStackName := fmt.Sprintf("mu-cluster-%s", groupEnvironment)
+		instanceIDs, err := instanceLister.ListStackInstances(groupStackName)
+		if err != nil {
+			return err
+		}
+		clusterParams["DistributionGroupInstanceIds"] = strings.Join(instanceIDs, ",")
+		return nil
+	}
+
+	if distribution == "spread-az" {
+		azCount := countSubnetAZs(clusterParams)
+		if azCount == 0 {
+			return fmt.Errorf("spread-az instanceDistribution requires at least one resolved subnet AZ")
+		}
+
+		minPerAZ := environment.Cluster.DesiredSize / azCount
+		maxPerAZ := environment.Cluster.MaxSize / azCount
+		if maxPerAZ < 1 {
+			maxPerAZ = 1
+		}
+		clusterParams["MinInstancesPerAZ"] = strconv.Itoa(minPerAZ)
+		clusterParams["MaxInstancesPerAZ"] = strconv.Itoa(maxPerAZ)
+
+		// CloudFormation has no arithmetic intrinsic, so the per-AZ counts are
+		// multiplied out here into the cluster-wide MinSize/MaxSize the ASG
+		// actually takes as parameters.
+		clusterParams["MinSize"] = strconv.Itoa(minPerAZ * azCount)
+		clusterParams["MaxSize"] = strconv.Itoa(maxPerAZ * azCount)
+		return nil
+	}
+
+	return fmt.Errorf("unrecognized instanceDistribution '%s'", distribution)
+}
+
+// countSubnetAZs counts how many AZs are available in the subnet type the
+// cluster's instances will launch into.
+func countSubnetAZs(clusterParams map[string]string) int {
+	prefix := "PublicSubnetAZ"
+	if clusterParams["InstanceSubnetType"] == "private" {
+		prefix = "PrivateSubnetAZ"
+	}
+
+	count := 0
+	for i := 1; i <= 3; i++ {
+		if clusterParams[fmt.Sprintf("%s%dId", prefix, i)] != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// ecsTerminateStep deletes the `mu-cluster-<environment>` stack.
+type ecsTerminateStep struct{}
+
+func (step *ecsTerminateStep) Name() string { return "ecs-terminate" }
+
+func (step *ecsTerminateStep) Run(wctx *WorkflowContext) error {
+	clusterStackName := fmt.Sprintf("mu-cluster-%s", wctx.Environment.Name)
+	return terminateStack(wctx, clusterStackName)
+}
+
+// vpcTerminateStep deletes the `mu-vpc-<environment>` stack.
+type vpcTerminateStep struct{}
+
+func (step *vpcTerminateStep) Name() string { return "vpc-terminate" }
+
+func (step *vpcTerminateStep) Run(wctx *WorkflowContext) error {
+	vpcStackName := fmt.Sprintf("mu-vpc-%s", wctx.Environment.Name)
+	return terminateStack(wctx, vpcStackName)
+}
+
+// runCfnStep upserts an arbitrary named CloudFormation stack, letting users
+// hang custom resources (e.g. Route53 records) off pre/post hooks without
+// forking mu. params must include "stackName" and "templateAsset" (a name
+// registered in the templates package); any other params are passed through
+// as stack parameters.
+type runCfnStep struct {
+	params map[string]string
+}
+
+func (step *runCfnStep) Name() string { return "run-cfn" }
+
+func (step *runCfnStep) Run(wctx *WorkflowContext) error {
+	stackName := step.params["stackName"]
+	templateAsset := step.params["templateAsset"]
+	if stackName == "" || templateAsset == "" {
+		return fmt.Errorf("run-cfn step requires 'stackName' and 'templateAsset' params")
+	}
+
+	templateBody, err := templates.GetAsset(templateAsset)
+	if err != nil {
+		return err
+	}
+
+	stackParams := make(map[string]string, len(step.params))
+	for k, v := range step.params {
+		if k == "stackName" || k == "templateAsset" {
+			continue
+		}
+		stackParams[k] = v
+	}
+
+	tags := map[string]string{
+		"mu-type":        "custom",
+		"mu-environment": wctx.Environment.Name,
+	}
+	return upsertStack(wctx, stackName, templateBody, stackParams, tags)
+}
+
+// runShellStep runs an arbitrary shell command as a workflow step. params
+// must include "command"; stdout/stderr are passed through to the mu
+// process so users see the step's output inline. In dry-run mode the
+// command has real-world side effects mu can't plan or undo, so it's
+// skipped and recorded alongside the stack change sets instead of run.
+type runShellStep struct {
+	params map[string]string
+}
+
+func (step *runShellStep) Name() string { return "run-shell" }
+
+func (step *runShellStep) Run(wctx *WorkflowContext) error {
+	command := step.params["command"]
+	if command == "" {
+		return fmt.Errorf("run-shell step requires a 'command' param")
+	}
+
+	if wctx.DryRun {
+		wctx.ChangeSets = append(wctx.ChangeSets, &common.ChangeSetSummary{StackName: command, Action: "SKIP-SHELL"})
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// waitStackStep blocks until a named stack reaches a terminal status and
+// fails if that status is a rollback or failure - useful for a stack that
+// something other than an upsert step (e.g. a run-shell command) changed.
+type waitStackStep struct {
+	params map[string]string
+}
+
+func (step *waitStackStep) Name() string { return "wait-stack" }
+
+func (step *waitStackStep) Run(wctx *WorkflowContext) error {
+	stackName := step.params["stackName"]
+	if stackName == "" {
+		return fmt.Errorf("wait-stack step requires a 'stackName' param")
+	}
+
+	status := wctx.Context.StackManager.AwaitFinalStatus(stackName)
+	if strings.Contains(status, "ROLLBACK") || strings.Contains(status, "FAILED") {
+		return fmt.Errorf("stack '%s' ended in status '%s'", stackName, status)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package workflows
+
+// Executor is a single unit of work within a workflow. Workflows are built
+// by composing Executors, either directly or via newPipelineExecutor.
+type Executor func() error
+
+// newPipelineExecutor chains a series of Executors, running them in order
+// and stopping at the first error.
+func newPipelineExecutor(executors ...Executor) Executor {
+	return func() error {
+		for _, executor := range executors {
+			if err := executor(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
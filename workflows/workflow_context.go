@@ -0,0 +1,124 @@
+package workflows
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/stelligent/mu/common"
+)
+
+// WorkflowContext carries the state steps share as a named environment
+// workflow runs: the environment being acted on, the shared mu Context
+// (config and AWS clients), the CloudFormation parameters steps resolve
+// into as they run (e.g. vpc-upsert populates the subnet ids ecs-upsert
+// consumes), and an optional writer for steps that report back to the user.
+type WorkflowContext struct {
+	Context     *common.Context
+	Environment *common.Environment
+	StackParams map[string]string
+	Writer      io.Writer
+
+	// DryRun tells upsert/terminate steps to plan their change via
+	// CreateChangeSet (or, for terminate steps, simply record the stack
+	// they would delete) instead of mutating any stack, appending what they
+	// would have done to ChangeSets.
+	DryRun     bool
+	ChangeSets []*common.ChangeSetSummary
+}
+
+// Step is a single named unit of work within a named environment workflow.
+type Step interface {
+	Name() string
+	Run(wctx *WorkflowContext) error
+}
+
+// stepRegistry maps the built-in step type names usable in an environment's
+// `workflows` YAML to their constructors.
+var stepRegistry = map[string]func(params map[string]string) Step{
+	"vpc-upsert":    func(params map[string]string) Step { return new(vpcUpsertStep) },
+	"ecs-upsert":    func(params map[string]string) Step { return new(ecsUpsertStep) },
+	"vpc-terminate": func(params map[string]string) Step { return new(vpcTerminateStep) },
+	"ecs-terminate": func(params map[string]string) Step { return new(ecsTerminateStep) },
+	"run-cfn":       func(params map[string]string) Step { return &runCfnStep{params: params} },
+	"run-shell":     func(params map[string]string) Step { return &runShellStep{params: params} },
+	"wait-stack":    func(params map[string]string) Step { return &waitStackStep{params: params} },
+}
+
+// defaultWorkflowSteps are used when an environment doesn't declare a
+// workflow of the requested name, preserving mu's original provision/
+// decommission behavior for environments that don't need to customize it.
+var defaultWorkflowSteps = map[string][]common.WorkflowStep{
+	"provision":    {{Type: "vpc-upsert"}, {Type: "ecs-upsert"}},
+	"decommission": {{Type: "ecs-terminate"}, {Type: "vpc-terminate"}},
+}
+
+// buildStep resolves a WorkflowStep declaration to a runnable Step.
+func buildStep(stepDef common.WorkflowStep) (Step, error) {
+	newStep, ok := stepRegistry[stepDef.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown workflow step type '%s'", stepDef.Type)
+	}
+	return newStep(stepDef.Params), nil
+}
+
+// runWorkflowSteps runs each step in order, running a step's pre-hooks
+// before it and its post-hooks after, stopping at the first error.
+func runWorkflowSteps(stepDefs []common.WorkflowStep, wctx *WorkflowContext) error {
+	for _, stepDef := range stepDefs {
+		if err := runWorkflowSteps(stepDef.Pre, wctx); err != nil {
+			return err
+		}
+
+		step, err := buildStep(stepDef)
+		if err != nil {
+			return err
+		}
+		if err := step.Run(wctx); err != nil {
+			return fmt.Errorf("step '%s' failed: %v", step.Name(), err)
+		}
+
+		if err := runWorkflowSteps(stepDef.Post, wctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyParamOverrides layers any user-supplied CloudFormation parameter
+// overrides (--param flags, MU_PARAM_* env vars, or config) on top of the
+// stack parameters a step computed.
+func applyParamOverrides(wctx *WorkflowContext, stackParams map[string]string) {
+	for key, value := range wctx.Context.Config.Parameters {
+		stackParams[key] = value
+	}
+}
+
+// renderChangeSets writes a consolidated, human-readable diff of every
+// change set and planned deletion collected during a dry run to wctx.Writer.
+func renderChangeSets(wctx *WorkflowContext) error {
+	if wctx.Writer == nil {
+		return nil
+	}
+
+	for _, summary := range wctx.ChangeSets {
+		if _, err := fmt.Fprintf(wctx.Writer, "%s %s\n", summary.Action, summary.StackName); err != nil {
+			return err
+		}
+		for _, change := range summary.ResourceChanges {
+			if _, err := fmt.Fprintf(wctx.Writer, "  resource: %s\n", change); err != nil {
+				return err
+			}
+		}
+		for _, change := range summary.ParameterChanges {
+			if _, err := fmt.Fprintf(wctx.Writer, "  parameter: %s\n", change); err != nil {
+				return err
+			}
+		}
+		for _, retained := range summary.RetainedResources {
+			if _, err := fmt.Fprintf(wctx.Writer, "  retained: %s\n", retained); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}